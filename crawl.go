@@ -15,19 +15,59 @@ import (
 	"log"
 	"net/http"
 	"net/url"
-	"regexp"
 	"sync"
+	"time"
 )
 
+// LinkInfo describes a single URL queued for crawling, together with the
+// metadata Scopes need to make depth- and source-aware decisions: how many
+// hops it is from a starting URL, and the URL it was discovered on.
+type LinkInfo struct {
+	URL       string
+	Depth     int
+	SourceURL string
+	// Tag classifies why this link was queued. TagRelated links are allowed
+	// one hop past the crawl's normal depth limit (every Scope still applies)
+	// so a page's embedded resources can still be fetched, and only the
+	// related resources found in them are ever queued from them. The zero
+	// value is TagPrimary.
+	Tag LinkTag
+}
+
+// LinkTag classifies a LinkInfo as either primary navigation or an embedded
+// resource discovered alongside it.
+type LinkTag int
+
+const (
+	// TagPrimary marks a link found via ordinary anchor navigation. It is
+	// subject to the crawl's normal depth and scope limits, and is itself
+	// parsed for further links.
+	TagPrimary LinkTag = iota
+	// TagRelated marks an embedded resource - a stylesheet, script, image,
+	// or other <link>/<img>/<source>-style reference - needed to archive a
+	// page in full. Related links are fetched one hop beyond the page's own
+	// depth. They are only parsed through ParseV2, and only the TagRelated
+	// links found in them are queued.
+	TagRelated
+)
+
+// TaggedLink pairs a discovered URL with the LinkTag describing how it was
+// found, so a ParseFuncV2 can tell the spider which links are primary
+// navigation and which are embedded resources.
+type TaggedLink struct {
+	URL string
+	Tag LinkTag
+}
+
 // LinkStore defines the interface that any object that looks
 // to store, and manage, the toCrawl and the crawled lists should
 // implement. All the methods in this interface are expected to be thread safe.
 type LinkStore interface {
-	// GetLinks should return a string slice of links to crawl, in the
+	// GetLinks should return a slice of links to crawl, in the
 	// amount defined in the amount paramter.
-	GetLinks(amount int) []string
+	GetLinks(amount int) []LinkInfo
 	// AddToCrawl should add the link parameter to the to crawl list.
-	AddToCrawl(link string)
+	AddToCrawl(link LinkInfo)
 	// MoveToCrawled should delete the link in the to crawl list and
 	// place it in the crawled list.
 	MoveToCrawled(link string)
@@ -38,8 +78,16 @@ type LinkStore interface {
 
 // ParseFunc defines a function that takes a HTTP response,
 // and returns a string slice of further URLs to crawl.
+// Deprecated: set Spider.ParseV2 instead; Parse is only consulted when
+// ParseV2 is left unset. ParseV2 can distinguish a page's navigation links
+// from its embedded resources, which Parse's plain string slice cannot.
 type ParseFunc func(*http.Response) []string
 
+// ParseFuncV2 defines a function that takes a HTTP response and returns the
+// further URLs to crawl, each tagged as either primary navigation or a
+// related embedded resource. See TagPrimary and TagRelated.
+type ParseFuncV2 func(*http.Response) []TaggedLink
+
 // PreRequestFunc is a function that modifies an existing
 // http.Request object before it is made to a web server. It
 // can be used for, as an example, modifying the user agent
@@ -59,26 +107,58 @@ type Spider struct {
 	// StartingURLs is a string slice of all the URLs that will be loaded into
 	// the spider first. These should be used to seed the scanner.
 	StartingURLs []string
-	// AllowedDomains is a string slice with all the allowed domains. An empty
-	// slice will cause the spider to assume that there are no domains that are not allowed.
-	AllowedDomains []string
-	// DisallowedPages is a slice of regular expressions. Each expression is evaluated on all links
-	// returned from the Parse() function. If the expression matches then the link is not added to the
-	// to crawl list.
-	DisallowedPages []regexp.Regexp
+	// Scopes is the ordered set of Scope implementations that decide whether
+	// a discovered URL is crawled. A URL is only added to the crawl if every
+	// Scope's Check returns true. An empty slice allows everything through.
+	Scopes []Scope
 	// MaxPages is the maximum amount of pages to crawl before the scanner returns. A setting of zero or less
 	// causes the spider to assume there are no maximum pages.
 	MaxPages int
 	// MaxConcurrentRequests is the maximum amount of requests to run in parallel.
 	MaxConcurrentRequests int
 
+	// RespectRobots controls whether the spider fetches and honours each
+	// host's robots.txt before crawling a URL.
+	RespectRobots bool
+	// UserAgentToken is the name the spider looks for in robots.txt
+	// "User-agent:" lines. It defaults to "*" if left blank. getPage also
+	// sends it as the outgoing request's User-Agent header (unless it's
+	// left as the "*" wildcard), so the policy matched against robots.txt
+	// lines up with what the spider identifies as on the wire; Extender's
+	// RequestGet/Fetch hooks or the deprecated PreRequestMiddleware can
+	// still override it.
+	UserAgentToken string
+	// DefaultCrawlDelay is the minimum gap left between requests to the
+	// same host when robots.txt does not specify its own Crawl-delay.
+	DefaultCrawlDelay time.Duration
+
 	// The Parse function should emit a list of urls that should be added to the crawl.
+	// Deprecated: set ParseV2 instead; Parse is only consulted when ParseV2 is left unset.
 	Parse ParseFunc
+	// ParseV2 is the tag-aware successor to Parse: it emits TaggedLinks so
+	// the spider can tell primary navigation from embedded page resources.
+	// If set, it is used in preference to Parse.
+	ParseV2 ParseFuncV2
 	// PreRequestMiddleware is a slice of functions that implement PreRequestFunc. Each of these functions
-	// is called on the http.Request object before it is execute by the http.Client.
+	// is called on the http.Request object before it is execute by the http.Client. Deprecated: set
+	// Extender instead; PreRequestMiddleware is only consulted when Extender is left unset.
 	PreRequestMiddleware []PreRequestFunc
-	// The function that gets a web page. Should take a http.Request and return a http.Response
+	// The function that gets a web page. Should take a http.Request and return a http.Response.
+	// Deprecated: set Extender instead; Getter is only consulted when Extender is left unset.
 	Getter RequestFunc
+	// Extender receives the full set of crawl lifecycle hooks: fetching, filtering,
+	// delaying, logging, and more. If left nil, Start wires up a shim Extender built
+	// from Getter and PreRequestMiddleware so existing spiders keep working.
+	Extender Extender
+
+	// AutoDiscoverSitemaps, if true, seeds every newly discovered host's
+	// /robots.txt and /sitemap.xml as extra starting points the first time
+	// a link on that host is queued, so crawl coverage isn't limited to
+	// whatever ordinary link-following happens to turn up. Pair this with a
+	// ParseV2 that routes those responses on to a sitemap handler, such as
+	// plugins.DefaultDispatcher, so the URLs they contain actually get
+	// queued.
+	AutoDiscoverSitemaps bool
 
 	// Verbose will cause more diagnostic information to be outputted if it's set to true.
 	Verbose bool
@@ -89,14 +169,17 @@ type Spider struct {
 	// and managing the crawled and the to crawl lists used by the spider during its operation.
 	Links LinkStore
 
-	hasAllowedDomains       bool
-	hasMaxPages             bool
-	hasPreRequestMiddleware bool
-	hasParse                bool
-	hasDisallowed           bool
+	hasMaxPages bool
+	hasParse    bool
+	hasParseV2  bool
 
 	totalSpidered int
 
+	throttler *HostThrottler
+
+	hostsMu         sync.Mutex
+	discoveredHosts map[string]bool
+
 	wg sync.WaitGroup
 }
 
@@ -104,7 +187,6 @@ type Spider struct {
 // arguments, and make sure each of the functions added are called
 // on the http.Request object before a request is made.
 func (s *Spider) AddPreRequestMiddleware(funcs ...PreRequestFunc) {
-	s.hasPreRequestMiddleware = true
 	for _, f := range funcs {
 		s.PreRequestMiddleware = append(s.PreRequestMiddleware, f)
 	}
@@ -119,23 +201,14 @@ func (s *Spider) Start() (err error) {
 	}
 
 	s.loadStartingURLS()
-	if !s.Quiet {
-		log.Println("[" + s.Name + "] Starting Spider")
-	}
+	s.Extender.Start()
+	s.Extender.Log("[" + s.Name + "] Starting Spider")
 	s.crawlLoop()
+	s.Extender.End()
 
 	return nil
 }
 
-func (s *Spider) processRequestMiddleware(req *http.Request) {
-	if !s.hasPreRequestMiddleware {
-		return
-	}
-	for _, m := range s.PreRequestMiddleware {
-		m(req)
-	}
-}
-
 func (s *Spider) validateSettings() error {
 	if s.Name == "" {
 		return errors.New("Crawls must have a name.")
@@ -149,14 +222,17 @@ func (s *Spider) validateSettings() error {
 		return errors.New("Spider must have a link store.")
 	}
 
-	if s.Getter == nil {
-		return errors.New("Spider must have a getter.")
+	if s.Extender == nil {
+		if s.Getter == nil {
+			return errors.New("Spider must have a getter.")
+		}
+		s.Extender = &shimExtender{spider: s}
 	}
 
-	if len(s.DisallowedPages) > 0 {
-		s.hasDisallowed = true
+	if s.ParseV2 != nil {
+		s.hasParseV2 = true
 	} else {
-		s.hasDisallowed = false
+		s.hasParseV2 = false
 	}
 
 	if s.Parse != nil {
@@ -165,28 +241,22 @@ func (s *Spider) validateSettings() error {
 		s.hasParse = false
 	}
 
-	if len(s.PreRequestMiddleware) > 0 {
-		s.hasPreRequestMiddleware = true
-	} else {
-		s.hasPreRequestMiddleware = false
-	}
-
 	if s.MaxPages <= 0 {
 		s.hasMaxPages = false
 	} else {
 		s.hasMaxPages = true
 	}
 
-	if len(s.AllowedDomains) == 0 {
-		s.hasAllowedDomains = false
-	} else {
-		s.hasAllowedDomains = true
-	}
-
 	if s.MaxConcurrentRequests <= 0 {
 		s.MaxConcurrentRequests = 1
 	}
 
+	if s.RespectRobots && s.UserAgentToken == "" {
+		s.UserAgentToken = "*"
+	}
+	s.throttler = NewHostThrottler()
+	s.discoveredHosts = make(map[string]bool)
+
 	s.totalSpidered = 0
 
 	return nil
@@ -213,73 +283,179 @@ func (s *Spider) crawlLoop() error {
 
 		s.wg.Add(len(temp)) // Add the amount of links to the wait group.
 
-		// Crawl each page, and call the parse function
-		for _, uri := range temp {
-			s.Links.MoveToCrawled(uri)
-			go s.getPage(uri)
+		// Crawl each page, and call the parse function. Rather than firing
+		// the whole batch at once, each request is scheduled against the
+		// next time its host is allowed a request, so MaxConcurrentRequests
+		// never causes the spider to violate a host's crawl-delay. That
+		// scheduling decision is made inside the per-request goroutine, not
+		// here: crawlDelayFor can block on a host's first robots.txt fetch,
+		// and doing that on this dispatch loop would serialize every other
+		// host's request behind it.
+		for _, link := range temp {
+			s.Links.MoveToCrawled(link.URL)
+
+			go s.getPageDelayed(link)
 			s.totalSpidered++
 		}
 		s.wg.Wait() // Wait for all the pages to be downloaded
 	}
 
-	if !s.Quiet {
-		log.Println("[" + s.Name + "] has completed.")
-	}
+	s.Extender.Log("[" + s.Name + "] has completed.")
 	return nil
 }
 
 func (s *Spider) loadStartingURLS() {
 	for _, link := range s.StartingURLs {
-		s.Links.AddToCrawl(link)
+		s.enqueue(LinkInfo{URL: link, Depth: 0, SourceURL: ""})
+	}
+}
+
+// enqueue adds link to the crawl's LinkStore, first seeding its host's
+// robots.txt and sitemap.xml as further starting points if
+// AutoDiscoverSitemaps is set and the host hasn't been seen before.
+func (s *Spider) enqueue(link LinkInfo) {
+	if s.AutoDiscoverSitemaps {
+		s.seedHostIfNew(link.URL)
+	}
+	s.Links.AddToCrawl(link)
+}
+
+// seedHostIfNew adds rawURL's host's /robots.txt and /sitemap.xml to the
+// crawl the first time that host is seen, at depth zero so they aren't
+// rejected out of hand by a DepthScope. They still go through the same
+// verifyAndEnqueue path as any other discovered link, so a Scope that
+// restricts the crawl to part of a host - a SeedScope bounding it to
+// "/blog/", say - can still reject them; AutoDiscoverSitemaps widens what
+// gets queued, it isn't a bypass of Scopes.
+func (s *Spider) seedHostIfNew(rawURL string) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return
+	}
+
+	s.hostsMu.Lock()
+	seen := s.discoveredHosts[u.Host]
+	s.discoveredHosts[u.Host] = true
+	s.hostsMu.Unlock()
+	if seen {
+		return
 	}
+
+	base := u.Scheme + "://" + u.Host
+	s.verifyAndEnqueue(base+"/robots.txt", TagPrimary, 0, rawURL)
+	s.verifyAndEnqueue(base+"/sitemap.xml", TagPrimary, 0, rawURL)
 }
 
-func (s *Spider) getPage(uri string) {
+// getPageDelayed works out how long to wait before link's host may be
+// requested again - honouring its crawl-delay, which may require fetching
+// and caching that host's robots.txt first - then sleeps that long before
+// crawling link. Doing the crawl-delay lookup here, inside the per-request
+// goroutine, keeps a slow first robots.txt fetch for one host from
+// stalling dispatch of every other host's request in the same batch.
+func (s *Spider) getPageDelayed(link LinkInfo) {
+	wait := time.Duration(0)
+	if u, err := url.Parse(link.URL); err == nil {
+		wait = s.throttler.Reserve(u.Host, s.crawlDelayFor(u))
+	}
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+	s.getPage(link)
+}
+
+func (s *Spider) getPage(link LinkInfo) {
+	uri := link.URL
+
 	// Make sure the page is okay to have a GET request issued.
-	err := s.verifyURL(uri)
+	err := s.verifyURL(uri, link.Depth, link.SourceURL)
 	defer func() {
 		s.wg.Done() // Make sure we mark this is done at the end of the function.
 	}()
 	if err != nil {
-		if !s.Quiet && s.Verbose {
-			log.Println("[" + s.Name + "] " + err.Error())
+		s.Extender.Disallowed(uri)
+		if s.Verbose {
+			s.Extender.Log("[" + s.Name + "] " + err.Error())
 		}
 		return
 	}
 
 	// The page is fine, we can now crawl it.
 	req, _ := http.NewRequest("GET", uri, nil)
-	s.processRequestMiddleware(req)
+	// Identify as the same UserAgentToken robots.txt rules were matched
+	// against, so the policy actually followed lines up with what goes out
+	// on the wire. "*" isn't a real product token, so it's left as the
+	// http.Client default rather than sent literally. RequestGet and
+	// PreRequestMiddleware run after this and may still override it.
+	if s.UserAgentToken != "" && s.UserAgentToken != "*" {
+		req.Header.Set("User-Agent", s.UserAgentToken)
+	}
+	s.Extender.RequestGet(req)
 
-	resp, err := s.Getter(req)
+	resp, err := s.Extender.Fetch(req)
 	if err != nil {
+		s.Extender.Error(err, uri)
 		return
 	}
 
-	if !s.Quiet {
-		log.Println("[" + s.Name + "] Spidered " + uri)
+	s.Extender.Log("[" + s.Name + "] Spidered " + uri)
+	s.Extender.Visited(uri)
+
+	// Related resources (embedded images, stylesheets, scripts, ...) are
+	// fetched so a page can be archived in full, but they never branch a
+	// crawl out through every page a <script src> happened to point at.
+	// ParseV2 is still consulted for them - a content-type aware Dispatcher
+	// can pull further url(...) references out of a linked stylesheet this
+	// way - but only the TagRelated links it returns are kept, so a related
+	// resource never adds primary links to the crawl. The legacy,
+	// untagged Parse has no such guarantee, so it's only run for primary
+	// pages, same as before.
+	if link.Tag == TagRelated && !s.hasParseV2 {
+		return
 	}
+
 	// Call the user defined parse function if it exists and add all links
 	// generated from it to the to crawl list
-	if s.hasParse {
-		links := s.Parse(resp)
-
-		// Add the parsed links to the list, provided
-		// it's a valid link
-		for _, l := range links {
-			err1 := s.verifyURL(l)
-			err2 := s.isPageDisallowed(l)
-			if err1 != nil || err2 != nil {
+	if s.hasParseV2 {
+		for _, l := range s.ParseV2(resp) {
+			if link.Tag == TagRelated && l.Tag != TagRelated {
 				continue
 			}
-
-			s.Links.AddToCrawl(l)
+			s.addParsedLink(l.URL, l.Tag, link.Depth, uri)
+		}
+	} else if s.hasParse {
+		for _, l := range s.Parse(resp) {
+			s.addParsedLink(l, TagPrimary, link.Depth, uri)
 		}
 	}
+}
+
+// addParsedLink verifies a link discovered while parsing sourceURL and, if
+// allowed, adds it to the crawl. Related resources are verified one depth
+// shallower than primary links, so a page at the crawl's maximum depth can
+// still pull in its own embedded resources.
+func (s *Spider) addParsedLink(l string, tag LinkTag, parentDepth int, sourceURL string) {
+	depth := parentDepth + 1
+	if tag == TagRelated {
+		depth = parentDepth
+	}
 
+	s.verifyAndEnqueue(l, tag, depth, sourceURL)
 }
 
-func (s *Spider) verifyURL(uri string) error {
+// verifyAndEnqueue runs the same Scope/Filter/robots.txt checks every other
+// discovered link goes through, via verifyURL, before adding l to the
+// crawl. A link that fails verification is reported to Extender.Disallowed
+// and dropped, same as one found while parsing a page.
+func (s *Spider) verifyAndEnqueue(l string, tag LinkTag, depth int, sourceURL string) {
+	if err := s.verifyURL(l, depth, sourceURL); err != nil {
+		s.Extender.Disallowed(l)
+		return
+	}
+
+	s.enqueue(LinkInfo{URL: l, Depth: depth, SourceURL: sourceURL, Tag: tag})
+}
+
+func (s *Spider) verifyURL(uri string, depth int, sourceURL string) error {
 	u, err := url.Parse(uri)
 	if err != nil {
 		return err
@@ -289,28 +465,24 @@ func (s *Spider) verifyURL(uri string) error {
 		return errors.New(uri + " not an absolute URL.")
 	}
 
-	shouldContinue := false
-	for _, e := range s.AllowedDomains {
-		if e == u.Host {
-			shouldContinue = true
-		}
+	if !s.checkScopes(uri, depth, sourceURL) || !s.Extender.Filter(uri, sourceURL, depth) {
+		return errors.New(uri + " is out of scope.")
 	}
-	if !shouldContinue {
-		return errors.New(uri + " not listed as allowed in spider settings.")
+
+	if s.RespectRobots && !s.robotsAllow(u) {
+		return errors.New(uri + " disallowed by robots.txt.")
 	}
 
 	return nil
 }
 
-func (s *Spider) isPageDisallowed(uri string) error {
-	if !s.hasDisallowed {
-		return nil
-	}
-	for _, r := range s.DisallowedPages {
-		if len(r.FindAllString(uri, 1)) > 0 {
-			return errors.New(uri + " is disallowed.")
+// checkScopes reports whether uri, discovered at depth from sourceURL,
+// satisfies every configured Scope.
+func (s *Spider) checkScopes(uri string, depth int, sourceURL string) bool {
+	for _, scope := range s.Scopes {
+		if !scope.Check(uri, depth, sourceURL) {
+			return false
 		}
 	}
-
-	return nil
+	return true
 }
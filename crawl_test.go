@@ -0,0 +1,54 @@
+package goatscrape
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// captureUAExtender is a minimal Extender that records the User-Agent header
+// on the request it was asked to Fetch, so getPage's request construction
+// can be exercised without a real HTTP round trip.
+type captureUAExtender struct {
+	DefaultExtender
+
+	gotUserAgent string
+}
+
+func (e *captureUAExtender) Filter(uri, sourceURL string, depth int) bool { return true }
+
+func (e *captureUAExtender) Fetch(req *http.Request) (*http.Response, error) {
+	e.gotUserAgent = req.Header.Get("User-Agent")
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+}
+
+func TestGetPageSetsUserAgentFromToken(t *testing.T) {
+	tests := []struct {
+		name           string
+		userAgentToken string
+		want           string
+	}{
+		{name: "explicit token is sent as User-Agent", userAgentToken: "MyBot", want: "MyBot"},
+		{name: "wildcard token is not sent literally", userAgentToken: "*", want: ""},
+		{name: "empty token leaves the header unset", userAgentToken: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ext := &captureUAExtender{}
+			s := &Spider{
+				Name:           "test",
+				UserAgentToken: tt.userAgentToken,
+				Extender:       ext,
+			}
+
+			s.wg.Add(1)
+			s.getPage(LinkInfo{URL: "http://example.com/"})
+
+			if ext.gotUserAgent != tt.want {
+				t.Errorf("User-Agent = %q, want %q", ext.gotUserAgent, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package goatscrape
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// HandlerFunc parses a http.Response a Dispatcher has already routed to it,
+// returning the TaggedLinks found in it.
+type HandlerFunc func(resp *http.Response) []TaggedLink
+
+// Dispatcher routes a crawled response to the first registered HandlerFunc
+// whose route matches, trying routes in registration order, so more
+// specific routes should be registered before general fallbacks. Dispatch
+// has the same signature as ParseFuncV2, so a configured Dispatcher can be
+// assigned straight to Spider.ParseV2.
+type Dispatcher struct {
+	routes []dispatchRoute
+}
+
+type dispatchRoute struct {
+	match   func(resp *http.Response) bool
+	handler HandlerFunc
+}
+
+// NewDispatcher returns an empty Dispatcher.
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{}
+}
+
+// Register adds a route that runs handler for any response for which match
+// returns true.
+func (d *Dispatcher) Register(match func(resp *http.Response) bool, handler HandlerFunc) {
+	d.routes = append(d.routes, dispatchRoute{match: match, handler: handler})
+}
+
+// RegisterContentType registers handler for responses whose Content-Type
+// header contains contentType, e.g. "html" or "json".
+func (d *Dispatcher) RegisterContentType(contentType string, handler HandlerFunc) {
+	d.Register(func(resp *http.Response) bool {
+		return strings.Contains(resp.Header.Get("Content-Type"), contentType)
+	}, handler)
+}
+
+// RegisterURLPattern registers handler for responses whose request URL
+// matches pattern, regardless of Content-Type. This is how resources such
+// as sitemap.xml and robots.txt, which are frequently served with a generic
+// or plain wrong Content-Type, get routed to the right handler.
+func (d *Dispatcher) RegisterURLPattern(pattern *regexp.Regexp, handler HandlerFunc) {
+	d.Register(func(resp *http.Response) bool {
+		return pattern.MatchString(resp.Request.URL.String())
+	}, handler)
+}
+
+// Dispatch finds the first registered route matching resp and runs its
+// handler, or returns nil if no route matches.
+func (d *Dispatcher) Dispatch(resp *http.Response) []TaggedLink {
+	for _, r := range d.routes {
+		if r.match(resp) {
+			return r.handler(resp)
+		}
+	}
+	return nil
+}
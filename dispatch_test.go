@@ -0,0 +1,78 @@
+package goatscrape
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+)
+
+func TestDispatcherRoutesInRegistrationOrder(t *testing.T) {
+	d := NewDispatcher()
+
+	d.RegisterURLPattern(regexp.MustCompile(`sitemap\.xml$`), func(resp *http.Response) []TaggedLink {
+		return []TaggedLink{{URL: "from-url-pattern", Tag: TagPrimary}}
+	})
+	d.RegisterContentType("xml", func(resp *http.Response) []TaggedLink {
+		return []TaggedLink{{URL: "from-content-type", Tag: TagPrimary}}
+	})
+
+	tests := []struct {
+		name        string
+		url         string
+		contentType string
+		want        string
+	}{
+		{
+			name:        "URL pattern route wins when both would match",
+			url:         "https://example.com/sitemap.xml",
+			contentType: "application/xml",
+			want:        "from-url-pattern",
+		},
+		{
+			name:        "falls through to content-type route when the URL doesn't match",
+			url:         "https://example.com/feed.xml",
+			contentType: "application/xml",
+			want:        "from-content-type",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := mustParseURL(t, tt.url)
+			header := make(http.Header)
+			header.Set("Content-Type", tt.contentType)
+			resp := &http.Response{Request: &http.Request{URL: u}, Header: header}
+
+			links := d.Dispatch(resp)
+			if len(links) != 1 || links[0].URL != tt.want {
+				t.Errorf("Dispatch() = %v, want a single link %q", links, tt.want)
+			}
+		})
+	}
+}
+
+func TestDispatcherNoMatchingRouteReturnsNil(t *testing.T) {
+	d := NewDispatcher()
+	d.RegisterContentType("html", func(resp *http.Response) []TaggedLink {
+		return []TaggedLink{{URL: "should not be returned", Tag: TagPrimary}}
+	})
+
+	u := mustParseURL(t, "https://example.com/data.json")
+	header := make(http.Header)
+	header.Set("Content-Type", "application/json")
+	resp := &http.Response{Request: &http.Request{URL: u}, Header: header}
+
+	if links := d.Dispatch(resp); links != nil {
+		t.Errorf("Dispatch() = %v, want nil", links)
+	}
+}
+
+func mustParseURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("url.Parse(%q) error = %v", raw, err)
+	}
+	return u
+}
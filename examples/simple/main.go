@@ -7,20 +7,20 @@ package main
 import (
 	"net/http"
 
-	"github.com/stevie-holdway/goscrape"
-	"github.com/stevie-holdway/goscrape/plugins"
+	"github.com/stevie-holdway/goatscrape"
+	"github.com/stevie-holdway/goatscrape/plugins"
 )
 
 func main() {
-	example := goscrape.Spider{
+	example := goatscrape.Spider{
 		Name: "Example 1",
 		StartingURLs: []string{
 			"http://www.xkcd.com/",
 			"http://www.xkcd.com/1/",
 			"http://www.xkcd.com/2/",
 		},
-		AllowedDomains: []string{
-			"www.xkcd.com",
+		Scopes: []goatscrape.Scope{
+			&goatscrape.HostScope{Hosts: []string{"www.xkcd.com"}},
 		},
 		MaxPages:              10,
 		MaxConcurrentRequests: 5,
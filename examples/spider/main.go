@@ -19,10 +19,10 @@ func main() {
 		StartingURLs: []string{
 			"http://www.xkcd.com/",
 		},
-		AllowedDomains: []string{
-			"www.xkcd.com",
+		Scopes: []goatscrape.Scope{
+			&goatscrape.HostScope{Hosts: []string{"www.xkcd.com"}},
+			&goatscrape.RegexScope{Pattern: regexp.MustCompile("http://www.xkcd.com/about"), Allow: false},
 		},
-		DisallowedPages:       []regexp.Regexp{*regexp.MustCompile("http://www.xkcd.com/about")},
 		MaxPages:              10,
 		MaxConcurrentRequests: 1,
 		Getter:                plugins.BasicGetter,
@@ -0,0 +1,108 @@
+package goatscrape
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Extender is the full set of lifecycle hooks a Spider dispatches to
+// during a crawl, in the spirit of gocrawl's Extender. It replaces the
+// old single-purpose Getter, Parse-adjacent PreRequestMiddleware, and
+// ad-hoc logging calls with one place to hook the whole crawl lifecycle.
+// Embed DefaultExtender to get sensible no-op defaults for any hooks a
+// caller doesn't need to override.
+type Extender interface {
+	// Start is called once, before the crawl's first request.
+	Start()
+	// End is called once, after the crawl has finished.
+	End()
+	// Error is called whenever a request made through Fetch fails.
+	Error(err error, uri string)
+	// ComputeDelay returns the crawl-delay to use for the next request to
+	// host, given lastFetch was the time of the previous one. A return
+	// value of zero or less defers to the spider's robots.txt/
+	// DefaultCrawlDelay policy.
+	ComputeDelay(host string, lastFetch time.Time) time.Duration
+	// Filter is called for every URL that has already passed the
+	// spider's Scopes; returning false drops it from the crawl.
+	Filter(uri, sourceURL string, depth int) bool
+	// Fetch performs req and returns its response. This is the same role
+	// the old Spider.Getter field played.
+	Fetch(req *http.Request) (*http.Response, error)
+	// RequestGet is called on req just before Fetch, the same role the
+	// old PreRequestMiddleware slice played.
+	RequestGet(req *http.Request)
+	// RequestRobots is called to obtain host's robots.txt body. Returning
+	// nil falls back to the spider fetching it directly over HTTP.
+	RequestRobots(host string) []byte
+	// Visited is called after uri has been successfully crawled.
+	Visited(uri string)
+	// Disallowed is called whenever a URL is rejected before crawling,
+	// whether by Scopes, Filter, or robots.txt.
+	Disallowed(uri string)
+	// Log is called for diagnostic messages the spider would otherwise
+	// print directly.
+	Log(args ...interface{})
+}
+
+// DefaultExtender is a no-op implementation of Extender. Embed it in a
+// custom extender so only the hooks that matter need overriding.
+type DefaultExtender struct{}
+
+func (DefaultExtender) Start() {}
+func (DefaultExtender) End()   {}
+
+func (DefaultExtender) Error(err error, uri string) {}
+
+func (DefaultExtender) ComputeDelay(host string, lastFetch time.Time) time.Duration {
+	return 0
+}
+
+func (DefaultExtender) Filter(uri, sourceURL string, depth int) bool {
+	return true
+}
+
+func (DefaultExtender) Fetch(req *http.Request) (*http.Response, error) {
+	return nil, errors.New("goatscrape: no Fetch implementation configured on the Extender")
+}
+
+func (DefaultExtender) RequestGet(req *http.Request) {}
+
+func (DefaultExtender) RequestRobots(host string) []byte {
+	return nil
+}
+
+func (DefaultExtender) Visited(uri string) {}
+
+func (DefaultExtender) Disallowed(uri string) {}
+
+func (DefaultExtender) Log(args ...interface{}) {}
+
+// shimExtender adapts a Spider's legacy Getter and PreRequestMiddleware
+// fields onto the Extender interface, so spiders configured before
+// Extender existed keep working unchanged when Spider.Extender is left
+// unset.
+type shimExtender struct {
+	DefaultExtender
+
+	spider *Spider
+}
+
+func (e *shimExtender) Fetch(req *http.Request) (*http.Response, error) {
+	return e.spider.Getter(req)
+}
+
+func (e *shimExtender) RequestGet(req *http.Request) {
+	for _, m := range e.spider.PreRequestMiddleware {
+		m(req)
+	}
+}
+
+func (e *shimExtender) Log(args ...interface{}) {
+	if e.spider.Quiet {
+		return
+	}
+	log.Println(args...)
+}
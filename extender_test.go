@@ -0,0 +1,51 @@
+package goatscrape
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestShimExtenderFetchDelegatesToGetter(t *testing.T) {
+	want := &http.Response{StatusCode: 200}
+	spider := &Spider{
+		Getter: RequestFunc(func(req *http.Request) (*http.Response, error) {
+			return want, nil
+		}),
+	}
+	shim := &shimExtender{spider: spider}
+
+	got, err := shim.Fetch(&http.Request{})
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("Fetch() = %v, want the response from spider.Getter", got)
+	}
+}
+
+func TestShimExtenderRequestGetRunsPreRequestMiddlewareInOrder(t *testing.T) {
+	var order []int
+	spider := &Spider{
+		PreRequestMiddleware: []PreRequestFunc{
+			func(req *http.Request) { order = append(order, 1) },
+			func(req *http.Request) { order = append(order, 2) },
+		},
+	}
+	shim := &shimExtender{spider: spider}
+
+	shim.RequestGet(&http.Request{})
+
+	want := []int{1, 2}
+	if len(order) != len(want) || order[0] != want[0] || order[1] != want[1] {
+		t.Errorf("RequestGet() ran middleware in order %v, want %v", order, want)
+	}
+}
+
+func TestShimExtenderLogRespectsQuiet(t *testing.T) {
+	spider := &Spider{Quiet: true}
+	shim := &shimExtender{spider: spider}
+
+	// Log must not panic and must be a no-op when the spider is quiet;
+	// there's no output to assert on beyond that.
+	shim.Log("this should not print")
+}
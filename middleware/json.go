@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/stevie-holdway/goatscrape"
+)
+
+// JSONSelector picks the URLs a caller cares about out of doc, the value
+// encoding/json decodes a response body into generically: a
+// map[string]interface{}, a []interface{}, or a scalar, depending on the
+// document's shape. JSONPath is a minimal helper for the common case of
+// "the links live in this one nested field".
+type JSONSelector func(doc interface{}) []string
+
+// NewJSONHandler returns a goatscrape.HandlerFunc that decodes a JSON
+// response body and runs selector over it to find further URLs to crawl,
+// each tagged TagPrimary. goatscrape doesn't depend on a full JSONPath
+// library, so callers write the walk themselves - typically with JSONPath
+// for a simple nested field, or by hand for anything selector syntax
+// doesn't cover.
+func NewJSONHandler(selector JSONSelector) goatscrape.HandlerFunc {
+	return func(resp *http.Response) []goatscrape.TaggedLink {
+		defer resp.Body.Close()
+
+		var doc interface{}
+		if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+			return nil
+		}
+
+		var links []goatscrape.TaggedLink
+		for _, uri := range selector(doc) {
+			links = append(links, goatscrape.TaggedLink{URL: uri, Tag: goatscrape.TagPrimary})
+		}
+		return links
+	}
+}
+
+// JSONPath walks doc along a dotted path such as "data.items" and returns
+// the value found there, or nil if the path doesn't resolve. It's a
+// deliberately minimal stand-in for a full JSONPath implementation: no
+// wildcards, filters, or array indices, just nested object keys.
+func JSONPath(doc interface{}, path string) interface{} {
+	cur := doc
+	for _, key := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		cur, ok = m[key]
+		if !ok {
+			return nil
+		}
+	}
+	return cur
+}
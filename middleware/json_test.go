@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestJSONPath(t *testing.T) {
+	doc := map[string]interface{}{
+		"data": map[string]interface{}{
+			"items": []interface{}{"a", "b"},
+		},
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want interface{}
+	}{
+		{name: "nested path resolves", path: "data.items", want: []interface{}{"a", "b"}},
+		{name: "missing key returns nil", path: "data.missing", want: nil},
+		{name: "path through a non-object returns nil", path: "data.items.items", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := JSONPath(doc, tt.path)
+
+			gotItems, gotOK := got.([]interface{})
+			wantItems, wantOK := tt.want.([]interface{})
+			if gotOK != wantOK {
+				t.Fatalf("JSONPath(%q) = %v (%T), want %v", tt.path, got, got, tt.want)
+			}
+			if gotOK {
+				if len(gotItems) != len(wantItems) {
+					t.Fatalf("JSONPath(%q) = %v, want %v", tt.path, gotItems, wantItems)
+				}
+				for i := range gotItems {
+					if gotItems[i] != wantItems[i] {
+						t.Errorf("JSONPath(%q)[%d] = %v, want %v", tt.path, i, gotItems[i], wantItems[i])
+					}
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("JSONPath(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewJSONHandler(t *testing.T) {
+	body := `{"data":{"items":["https://example.com/a","https://example.com/b"]}}`
+	resp := &http.Response{Body: io.NopCloser(bytes.NewReader([]byte(body)))}
+
+	handler := NewJSONHandler(func(doc interface{}) []string {
+		items, _ := JSONPath(doc, "data.items").([]interface{})
+		var urls []string
+		for _, item := range items {
+			if s, ok := item.(string); ok {
+				urls = append(urls, s)
+			}
+		}
+		return urls
+	})
+
+	links := handler(resp)
+	if len(links) != 2 {
+		t.Fatalf("got %d links, want 2: %v", len(links), links)
+	}
+	want := []string{"https://example.com/a", "https://example.com/b"}
+	for i, l := range links {
+		if l.URL != want[i] {
+			t.Errorf("link[%d] = %q, want %q", i, l.URL, want[i])
+		}
+	}
+}
@@ -1,14 +1,20 @@
 package middleware
 
 import (
+	"io"
 	"net/http"
+	"net/url"
+	"regexp"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/stevie-holdway/goatscrape"
 )
 
 // ExtractAllLinks simply extracts all the <a href="<urL>"> </a> in a page
 // provided that they aren't silly like a hash. It also expands relative
 // links automagically.
+// Deprecated: use ExtractAllLinksV2 with Spider.ParseV2 instead; it also
+// picks up a page's embedded resources so it can be archived in full.
 func ExtractAllLinks(resp *http.Response) []string {
 	var links []string
 
@@ -42,3 +48,98 @@ func ExtractAllLinks(resp *http.Response) []string {
 
 	return links
 }
+
+// cssURLPattern matches the target of a `url(...)` reference that follows a
+// property's colon or an @import with only whitespace in between, so
+// ExtractAllLinksV2 can follow background images, @font-face sources and
+// @import rules that live in a <style> block. A url(...) later in a value,
+// such as after a colour in a background shorthand or a second @font-face
+// source, is not matched.
+var cssURLPattern = regexp.MustCompile(`(?:@import|:)\s*url\(["']?([^'"\)]+)["']?\)`)
+
+// ExtractAllLinksV2 is the goatscrape.ParseFuncV2 successor to
+// ExtractAllLinks. Anchor links are tagged goatscrape.TagPrimary, same as
+// before; everything needed to render the page in full - <link rel>, <img
+// src>, <script src>, <source src>, and url(...) references inside inline
+// <style> blocks - is tagged goatscrape.TagRelated, so the Spider fetches
+// it for archiving purposes without treating it as a page to crawl onward
+// from. url(...) references inside an externally linked stylesheet aren't
+// followed here, since that file is only tagged TagRelated and this
+// function only understands HTML; register ExtractCSSLinks against text/css
+// responses on the Dispatcher used as ParseV2 (DefaultDispatcher does this)
+// to pull in an external stylesheet's own url(...) references too.
+func ExtractAllLinksV2(resp *http.Response) []goatscrape.TaggedLink {
+	var links []goatscrape.TaggedLink
+
+	base := *resp.Request.URL
+	base.Fragment = ""
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return links
+	}
+
+	add := func(tag goatscrape.LinkTag, raw string) {
+		if raw == "" || raw[0] == '#' {
+			return
+		}
+		ref, err := url.Parse(raw)
+		if err != nil {
+			return
+		}
+		links = append(links, goatscrape.TaggedLink{URL: base.ResolveReference(ref).String(), Tag: tag})
+	}
+
+	doc.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		add(goatscrape.TagPrimary, href)
+	})
+
+	doc.Find("link[href]").Each(func(i int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		add(goatscrape.TagRelated, href)
+	})
+
+	doc.Find("img[src], script[src], source[src]").Each(func(i int, s *goquery.Selection) {
+		src, _ := s.Attr("src")
+		add(goatscrape.TagRelated, src)
+	})
+
+	doc.Find("style").Each(func(i int, s *goquery.Selection) {
+		for _, m := range cssURLPattern.FindAllStringSubmatch(s.Text(), -1) {
+			add(goatscrape.TagRelated, m[1])
+		}
+	})
+
+	return links
+}
+
+// ExtractCSSLinks is a goatscrape.HandlerFunc that regex-scans a fetched
+// CSS file for url(...) references the same way ExtractAllLinksV2 scans an
+// inline <style> block, so a page's externally linked stylesheets still
+// contribute their fonts, background images, and @import targets to an
+// archived crawl. Every link is tagged goatscrape.TagRelated: a stylesheet
+// is a resource to fetch for completeness, never a page to branch out
+// from. Register it against text/css responses on a Dispatcher (e.g. via
+// DefaultDispatcher) rather than assigning it to Spider.ParseV2 directly.
+func ExtractCSSLinks(resp *http.Response) []goatscrape.TaggedLink {
+	raw, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil
+	}
+
+	base := *resp.Request.URL
+	base.Fragment = ""
+
+	var links []goatscrape.TaggedLink
+	for _, m := range cssURLPattern.FindAllStringSubmatch(string(raw), -1) {
+		ref, err := url.Parse(m[1])
+		if err != nil {
+			continue
+		}
+		links = append(links, goatscrape.TaggedLink{URL: base.ResolveReference(ref).String(), Tag: goatscrape.TagRelated})
+	}
+	return links
+}
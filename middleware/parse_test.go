@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stevie-holdway/goatscrape"
+)
+
+func TestExtractAllLinksV2TagAssignment(t *testing.T) {
+	body := `
+		<html>
+		<head>
+			<link rel="stylesheet" href="/style.css">
+			<style>
+				@import url("/fonts/imported.css");
+				.hero { background: url('/img/hero.png'); }
+			</style>
+		</head>
+		<body>
+			<a href="/about">About</a>
+			<a href="#skip">Skip</a>
+			<img src="/img/logo.png">
+			<script src="/app.js"></script>
+			<source src="/video.mp4">
+		</body>
+		</html>`
+
+	reqURL, err := url.Parse("https://example.com/page")
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+	resp := &http.Response{
+		Request: &http.Request{URL: reqURL},
+		Body:    io.NopCloser(strings.NewReader(body)),
+	}
+
+	links := ExtractAllLinksV2(resp)
+
+	want := map[string]goatscrape.LinkTag{
+		"https://example.com/about":             goatscrape.TagPrimary,
+		"https://example.com/style.css":          goatscrape.TagRelated,
+		"https://example.com/fonts/imported.css": goatscrape.TagRelated,
+		"https://example.com/img/hero.png":       goatscrape.TagRelated,
+		"https://example.com/img/logo.png":       goatscrape.TagRelated,
+		"https://example.com/app.js":             goatscrape.TagRelated,
+		"https://example.com/video.mp4":          goatscrape.TagRelated,
+	}
+
+	got := make(map[string]goatscrape.LinkTag)
+	for _, l := range links {
+		got[l.URL] = l.Tag
+	}
+
+	for url, tag := range want {
+		gotTag, ok := got[url]
+		if !ok {
+			t.Errorf("missing expected link %q", url)
+			continue
+		}
+		if gotTag != tag {
+			t.Errorf("link %q tag = %v, want %v", url, gotTag, tag)
+		}
+	}
+
+	if _, ok := got["https://example.com#skip"]; ok {
+		t.Errorf("fragment-only link should have been skipped")
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d distinct links, want %d: %v", len(got), len(want), got)
+	}
+}
+
+func TestExtractCSSLinks(t *testing.T) {
+	body := `
+		@import url("/fonts/imported.css");
+		.hero { background: url('/img/hero.png'); }
+		.logo { background-image:url(../img/logo.png); }`
+
+	reqURL, err := url.Parse("https://example.com/css/site.css")
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+	resp := &http.Response{
+		Request: &http.Request{URL: reqURL},
+		Body:    io.NopCloser(strings.NewReader(body)),
+	}
+
+	links := ExtractCSSLinks(resp)
+
+	want := map[string]goatscrape.LinkTag{
+		"https://example.com/fonts/imported.css": goatscrape.TagRelated,
+		"https://example.com/img/hero.png":       goatscrape.TagRelated,
+		"https://example.com/img/logo.png":       goatscrape.TagRelated,
+	}
+
+	got := make(map[string]goatscrape.LinkTag)
+	for _, l := range links {
+		got[l.URL] = l.Tag
+	}
+
+	for url, tag := range want {
+		gotTag, ok := got[url]
+		if !ok {
+			t.Errorf("missing expected link %q", url)
+			continue
+		}
+		if gotTag != tag {
+			t.Errorf("link %q tag = %v, want %v", url, gotTag, tag)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d distinct links, want %d: %v", len(got), len(want), got)
+	}
+}
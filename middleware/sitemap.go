@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/stevie-holdway/goatscrape"
+)
+
+// sitemapDoc covers just enough of the sitemaps.org schema to pull every
+// <loc> out of either a <urlset> (a plain sitemap) or a <sitemapindex> (a
+// sitemap of sitemaps) with the one struct.
+type sitemapDoc struct {
+	Locations []string `xml:"url>loc"`
+	Indexes   []string `xml:"sitemap>loc"`
+}
+
+// SitemapXMLHandler is a goatscrape.HandlerFunc that reads a sitemap.xml or
+// sitemapindex.xml document, transparently gunzipping it first if it was
+// served gzip-compressed (as sitemap-....xml.gz commonly is), and returns
+// every <loc> it contains as a TagPrimary link. A sitemapindex's <loc>
+// entries point at further sitemaps rather than pages; this handler
+// doesn't recurse into them itself, it simply re-queues them, and the
+// crawl naturally routes them back through SitemapXMLHandler once fetched.
+func SitemapXMLHandler(resp *http.Response) []goatscrape.TaggedLink {
+	raw, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil
+	}
+
+	body, err := maybeGunzip(resp, raw)
+	if err != nil {
+		return nil
+	}
+
+	var doc sitemapDoc
+	if err := xml.NewDecoder(body).Decode(&doc); err != nil {
+		return nil
+	}
+
+	var links []goatscrape.TaggedLink
+	for _, loc := range append(doc.Locations, doc.Indexes...) {
+		if loc = strings.TrimSpace(loc); loc != "" {
+			links = append(links, goatscrape.TaggedLink{URL: loc, Tag: goatscrape.TagPrimary})
+		}
+	}
+	return links
+}
+
+// maybeGunzip returns a reader over raw, gunzipping it first if resp looks
+// gzip-compressed: either a "Content-Encoding: gzip" header or, since many
+// servers leave that header off for static .xml.gz files, a ".gz" suffix on
+// the request URL's path.
+func maybeGunzip(resp *http.Response, raw []byte) (io.Reader, error) {
+	gzipped := strings.Contains(resp.Header.Get("Content-Encoding"), "gzip") ||
+		strings.HasSuffix(resp.Request.URL.Path, ".gz")
+	if !gzipped {
+		return bytes.NewReader(raw), nil
+	}
+
+	return gzip.NewReader(bytes.NewReader(raw))
+}
+
+// RobotsSitemapHandler is a goatscrape.HandlerFunc that scans a robots.txt
+// response for "Sitemap:" directives - part of the sitemaps.org protocol
+// rather than the original robots.txt spec, but supported by every crawler
+// that matters - and returns each one as a TagPrimary link, so the spider
+// pulls it in and a Dispatcher routes it on to SitemapXMLHandler.
+func RobotsSitemapHandler(resp *http.Response) []goatscrape.TaggedLink {
+	raw, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil
+	}
+
+	var links []goatscrape.TaggedLink
+	for _, line := range strings.Split(string(raw), "\n") {
+		line = strings.TrimSpace(line)
+		rest, ok := cutFold(line, "sitemap:")
+		if !ok {
+			continue
+		}
+		if uri := strings.TrimSpace(rest); uri != "" {
+			links = append(links, goatscrape.TaggedLink{URL: uri, Tag: goatscrape.TagPrimary})
+		}
+	}
+	return links
+}
+
+// cutFold reports whether line starts with prefix, ignoring case, and if so
+// returns the remainder of line after prefix.
+func cutFold(line, prefix string) (string, bool) {
+	if len(line) < len(prefix) || !strings.EqualFold(line[:len(prefix)], prefix) {
+		return "", false
+	}
+	return line[len(prefix):], true
+}
@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"testing"
+
+	"github.com/stevie-holdway/goatscrape"
+)
+
+func newSitemapResponse(t *testing.T, rawURL, contentEncoding string, body []byte) *http.Response {
+	t.Helper()
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+
+	header := make(http.Header)
+	if contentEncoding != "" {
+		header.Set("Content-Encoding", contentEncoding)
+	}
+
+	return &http.Response{
+		Request: &http.Request{URL: u},
+		Header:  header,
+		Body:    io.NopCloser(bytes.NewReader(body)),
+	}
+}
+
+func gzipBytes(t *testing.T, raw []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(raw); err != nil {
+		t.Fatalf("gzip write error = %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("gzip close error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func locURLs(links []goatscrape.TaggedLink) []string {
+	var urls []string
+	for _, l := range links {
+		urls = append(urls, l.URL)
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+func TestSitemapXMLHandler(t *testing.T) {
+	urlset := `<?xml version="1.0"?>
+		<urlset><url><loc>https://example.com/a</loc></url><url><loc>https://example.com/b</loc></url></urlset>`
+	sitemapindex := `<?xml version="1.0"?>
+		<sitemapindex><sitemap><loc>https://example.com/sitemap-1.xml</loc></sitemap></sitemapindex>`
+
+	tests := []struct {
+		name            string
+		rawURL          string
+		contentEncoding string
+		gzipped         bool
+		body            string
+		want            []string
+	}{
+		{
+			name:   "plain urlset",
+			rawURL: "https://example.com/sitemap.xml",
+			body:   urlset,
+			want:   []string{"https://example.com/a", "https://example.com/b"},
+		},
+		{
+			name:   "plain sitemapindex",
+			rawURL: "https://example.com/sitemap_index.xml",
+			body:   sitemapindex,
+			want:   []string{"https://example.com/sitemap-1.xml"},
+		},
+		{
+			name:            "gzip urlset via Content-Encoding header",
+			rawURL:          "https://example.com/sitemap.xml",
+			contentEncoding: "gzip",
+			gzipped:         true,
+			body:            urlset,
+			want:            []string{"https://example.com/a", "https://example.com/b"},
+		},
+		{
+			name:    "gzip urlset via .gz URL suffix",
+			rawURL:  "https://example.com/sitemap.xml.gz",
+			gzipped: true,
+			body:    urlset,
+			want:    []string{"https://example.com/a", "https://example.com/b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := []byte(tt.body)
+			if tt.gzipped {
+				body = gzipBytes(t, body)
+			}
+
+			resp := newSitemapResponse(t, tt.rawURL, tt.contentEncoding, body)
+			links := SitemapXMLHandler(resp)
+
+			got := locURLs(links)
+			want := append([]string(nil), tt.want...)
+			sort.Strings(want)
+
+			if len(got) != len(want) {
+				t.Fatalf("got %d links, want %d: %v", len(got), len(want), got)
+			}
+			for i := range got {
+				if got[i] != want[i] {
+					t.Errorf("link[%d] = %q, want %q", i, got[i], want[i])
+				}
+			}
+			for _, l := range links {
+				if l.Tag != goatscrape.TagPrimary {
+					t.Errorf("link %q tag = %v, want TagPrimary", l.URL, l.Tag)
+				}
+			}
+		})
+	}
+}
+
+func TestRobotsSitemapHandler(t *testing.T) {
+	body := "User-agent: *\nDisallow: /private\nSitemap: https://example.com/sitemap.xml\nsitemap: https://example.com/sitemap2.xml\n"
+
+	resp := &http.Response{Body: io.NopCloser(bytes.NewReader([]byte(body)))}
+	links := RobotsSitemapHandler(resp)
+
+	got := locURLs(links)
+	want := []string{"https://example.com/sitemap.xml", "https://example.com/sitemap2.xml"}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d links, want %d: %v", len(got), len(want), got)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("link[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package plugins
+
+import (
+	"regexp"
+
+	"github.com/stevie-holdway/goatscrape"
+	"github.com/stevie-holdway/goatscrape/middleware"
+)
+
+// sitemapPattern matches a sitemap.xml, sitemap index, or gzipped sitemap
+// URL - commonly /sitemap.xml, but also e.g. /sitemap_index.xml or one of
+// the per-shard files a sitemap index links to.
+var sitemapPattern = regexp.MustCompile(`(?i)sitemap[^/]*\.xml(\.gz)?$`)
+
+// robotsPattern matches a robots.txt URL.
+var robotsPattern = regexp.MustCompile(`(?i)/robots\.txt$`)
+
+// cssPattern matches a linked stylesheet URL, as a fallback for servers
+// that don't send a "text/css" Content-Type for one.
+var cssPattern = regexp.MustCompile(`(?i)\.css(\?.*)?$`)
+
+// DefaultDispatcher returns a goatscrape.Dispatcher pre-wired with
+// goatscrape's content-type aware pipeline: a robots.txt handler that
+// discovers further sitemaps, an XML handler for sitemap.xml/sitemapindex
+// documents, a CSS handler that pulls further url(...) references out of
+// linked stylesheets, and an HTML handler for ordinary pages. Assign its
+// Dispatch method to Spider.ParseV2, and pair it with
+// Spider.AutoDiscoverSitemaps so the spider seeds /robots.txt and
+// /sitemap.xml on every new host it visits.
+func DefaultDispatcher() *goatscrape.Dispatcher {
+	d := goatscrape.NewDispatcher()
+
+	d.RegisterURLPattern(robotsPattern, middleware.RobotsSitemapHandler)
+	d.RegisterURLPattern(sitemapPattern, middleware.SitemapXMLHandler)
+	d.RegisterContentType("xml", middleware.SitemapXMLHandler)
+	d.RegisterURLPattern(cssPattern, middleware.ExtractCSSLinks)
+	d.RegisterContentType("css", middleware.ExtractCSSLinks)
+	d.RegisterContentType("html", middleware.ExtractAllLinksV2)
+
+	return d
+}
@@ -6,30 +6,62 @@ import (
 	"errors"
 	"net/http"
 	"strings"
+
+	"github.com/stevie-holdway/goatscrape"
 )
 
 var client http.Client
 
+// defaultAllowedContentTypes is the set of Content-Type substrings
+// BasicGetter accepts: HTML pages plus XML (sitemaps), plain text
+// (robots.txt) and JSON, which a Dispatcher can route to SitemapXMLHandler,
+// RobotsSitemapHandler, or a handler from middleware.NewJSONHandler. Any
+// other type, text/css included, is rejected.
+var defaultAllowedContentTypes = []string{"html", "xml", "json", "text/plain"}
+
+// BasicGetter probes a URL with a HEAD request and only issues the GET if
+// the response's Content-Type looks like one of defaultAllowedContentTypes.
+// Deprecated: use ContentAwareGetter instead; BasicGetter is now just
+// ContentAwareGetter pinned to defaultAllowedContentTypes.
 func BasicGetter(req *http.Request) (*http.Response, error) {
-	// First make a head request to verify if the page is a html page
-	reqCopy := *req
+	return ContentAwareGetter(defaultAllowedContentTypes...)(req)
+}
 
-	reqCopy.Method = "HEAD"
+// ContentAwareGetter returns a goatscrape.RequestFunc that probes a URL
+// with a HEAD request and only issues the GET if the response's
+// Content-Type header contains one of allowedContentTypes, generalising
+// the old HTML-only check so content-type aware pipelines such as
+// Dispatcher can also pull in sitemap XML, robots.txt, and JSON responses.
+func ContentAwareGetter(allowedContentTypes ...string) goatscrape.RequestFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		// First make a head request to verify the page has an accepted content type.
+		reqCopy := *req
 
-	headResp, err := client.Do(&reqCopy)
-	if err != nil {
-		return nil, err
-	}
+		reqCopy.Method = "HEAD"
 
-	if !strings.Contains(headResp.Header.Get("Content-Type"), "html") {
-		return nil, errors.New("Page does not have a content type of html.")
-	}
+		headResp, err := client.Do(&reqCopy)
+		if err != nil {
+			return nil, err
+		}
 
-	// Now we have verified we have a html page, we can actually issue a get request.
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
+		contentType := headResp.Header.Get("Content-Type")
+		allowed := false
+		for _, ct := range allowedContentTypes {
+			if strings.Contains(contentType, ct) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return nil, errors.New("Page does not have an accepted content type.")
+		}
 
-	return resp, nil
+		// Now we have verified the content type is accepted, we can actually issue a get request.
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		return resp, nil
+	}
 }
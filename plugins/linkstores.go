@@ -1,15 +1,19 @@
 package plugins
 
-import "sync"
+import (
+	"sync"
+
+	"github.com/stevie-holdway/goatscrape"
+)
 
 type BasicLinkStore struct {
 	crawled []string
-	toCrawl []string
+	toCrawl []goatscrape.LinkInfo
 	sync.Mutex
 }
 
-func (b *BasicLinkStore) GetLinks(amount int) []string {
-	var links []string
+func (b *BasicLinkStore) GetLinks(amount int) []goatscrape.LinkInfo {
+	var links []goatscrape.LinkInfo
 	b.Lock()
 
 	counter := 0
@@ -27,18 +31,18 @@ func (b *BasicLinkStore) GetLinks(amount int) []string {
 	return links
 }
 
-func (b *BasicLinkStore) AddToCrawl(link string) {
+func (b *BasicLinkStore) AddToCrawl(link goatscrape.LinkInfo) {
 	b.Lock()
 
 	// Does the link already exist in either lists?
 	for _, l := range b.toCrawl {
-		if link == l {
+		if link.URL == l.URL {
 			b.Unlock()
 			return
 		}
 	}
 	for _, l := range b.crawled {
-		if link == l {
+		if link.URL == l {
 			b.Unlock()
 			return
 		}
@@ -55,7 +59,7 @@ func (b *BasicLinkStore) MoveToCrawled(link string) {
 	// Delete the item in the toCrawl list
 	canContinue := false
 	for i, l := range b.toCrawl {
-		if l == link {
+		if l.URL == link {
 			b.toCrawl = append(b.toCrawl[:i], b.toCrawl[i+1:]...)
 			canContinue = true
 		}
@@ -0,0 +1,206 @@
+package plugins
+
+import (
+	"container/list"
+	"encoding/json"
+	"log"
+	"net/url"
+	"sync"
+
+	"github.com/stevie-holdway/goatscrape"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	toCrawlBucket = []byte("toCrawl")
+	crawledBucket = []byte("crawled")
+)
+
+// Stats reports the queue depth and unique host count of a
+// PersistentLinkStore, for monitoring a long-running crawl.
+type Stats struct {
+	QueueDepth  int
+	UniqueHosts int
+}
+
+// PersistentLinkStore is a goatscrape.LinkStore backed by an embedded
+// BoltDB file, so a crawl's to-crawl and crawled lists survive process
+// restarts. BasicLinkStore.AddToCrawl and MoveToCrawled are both O(n) over
+// its slices, which becomes untenable past a few thousand URLs;
+// PersistentLinkStore instead keeps its queue as a container/list with a
+// URL-to-element index, so both dedup and removal are O(1), and on
+// creation resumes any links left over in the toCrawl bucket from a
+// previous run, so a crawl started with the same seeds picks up where it
+// left off.
+//
+// OnDBError, if set, is called whenever a write to the underlying BoltDB
+// file fails, so a caller can react (e.g. abort the crawl) instead of the
+// in-memory queue silently diverging from what's on disk. If it is nil,
+// the error is logged via the standard logger.
+type PersistentLinkStore struct {
+	OnDBError func(err error, op, url string)
+
+	db *bolt.DB
+
+	mu      sync.Mutex
+	queue   *list.List
+	byURL   map[string]*list.Element
+	crawled map[string]bool
+}
+
+// NewPersistentLinkStore opens (or creates) the BoltDB file at path and
+// resumes any links left in the toCrawl bucket from a previous run.
+func NewPersistentLinkStore(path string) (*PersistentLinkStore, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(toCrawlBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(crawledBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	store := &PersistentLinkStore{
+		db:      db,
+		queue:   list.New(),
+		byURL:   make(map[string]*list.Element),
+		crawled: make(map[string]bool),
+	}
+
+	if err := store.resume(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// resume loads any links left in the toCrawl and crawled buckets from a
+// previous run back into memory.
+func (p *PersistentLinkStore) resume() error {
+	return p.db.View(func(tx *bolt.Tx) error {
+		err := tx.Bucket(toCrawlBucket).ForEach(func(k, v []byte) error {
+			var link goatscrape.LinkInfo
+			if err := json.Unmarshal(v, &link); err != nil {
+				return err
+			}
+			p.byURL[link.URL] = p.queue.PushBack(link)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket(crawledBucket).ForEach(func(k, v []byte) error {
+			p.crawled[string(k)] = true
+			return nil
+		})
+	})
+}
+
+// reportDBError forwards a failed BoltDB write to OnDBError, or logs it if
+// no handler is set.
+func (p *PersistentLinkStore) reportDBError(err error, op, uri string) {
+	if p.OnDBError != nil {
+		p.OnDBError(err, op, uri)
+		return
+	}
+	log.Printf("[PersistentLinkStore] %s %q: %v", op, uri, err)
+}
+
+func (p *PersistentLinkStore) GetLinks(amount int) []goatscrape.LinkInfo {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var links []goatscrape.LinkInfo
+	for e := p.queue.Front(); e != nil && len(links) < amount; e = e.Next() {
+		links = append(links, e.Value.(goatscrape.LinkInfo))
+	}
+	return links
+}
+
+func (p *PersistentLinkStore) AddToCrawl(link goatscrape.LinkInfo) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.byURL[link.URL]; ok || p.crawled[link.URL] {
+		return
+	}
+	p.byURL[link.URL] = p.queue.PushBack(link)
+
+	err := p.db.Update(func(tx *bolt.Tx) error {
+		data, err := json.Marshal(link)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(toCrawlBucket).Put([]byte(link.URL), data)
+	})
+	if err != nil {
+		p.reportDBError(err, "AddToCrawl", link.URL)
+	}
+}
+
+func (p *PersistentLinkStore) MoveToCrawled(link string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if e, ok := p.byURL[link]; ok {
+		p.queue.Remove(e)
+		delete(p.byURL, link)
+	}
+	p.crawled[link] = true
+
+	err := p.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(toCrawlBucket).Delete([]byte(link)); err != nil {
+			return err
+		}
+		return tx.Bucket(crawledBucket).Put([]byte(link), []byte{1})
+	})
+	if err != nil {
+		p.reportDBError(err, "MoveToCrawled", link)
+	}
+}
+
+func (p *PersistentLinkStore) MoreToCrawl() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.queue.Len() > 0
+}
+
+// Stats returns the current queue depth and the number of unique hosts
+// still queued to crawl.
+func (p *PersistentLinkStore) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	hosts := make(map[string]bool)
+	for e := p.queue.Front(); e != nil; e = e.Next() {
+		if u, err := url.Parse(e.Value.(goatscrape.LinkInfo).URL); err == nil {
+			hosts[u.Host] = true
+		}
+	}
+
+	return Stats{QueueDepth: p.queue.Len(), UniqueHosts: len(hosts)}
+}
+
+// Checkpoint flushes the store's on-disk state so it is safe to resume
+// from after an unclean shutdown.
+func (p *PersistentLinkStore) Checkpoint() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.db.Sync()
+}
+
+// Close releases the underlying BoltDB file. It should be called when the
+// crawl finishes or is shutting down.
+func (p *PersistentLinkStore) Close() error {
+	return p.db.Close()
+}
@@ -0,0 +1,89 @@
+package plugins
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stevie-holdway/goatscrape"
+)
+
+func TestPersistentLinkStoreDedup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "links.db")
+
+	store, err := NewPersistentLinkStore(path)
+	if err != nil {
+		t.Fatalf("NewPersistentLinkStore() error = %v", err)
+	}
+	defer store.Close()
+
+	store.AddToCrawl(goatscrape.LinkInfo{URL: "https://example.com/a"})
+	store.AddToCrawl(goatscrape.LinkInfo{URL: "https://example.com/a"})
+
+	if got := store.Stats().QueueDepth; got != 1 {
+		t.Errorf("QueueDepth after duplicate AddToCrawl = %d, want 1", got)
+	}
+
+	store.MoveToCrawled("https://example.com/a")
+	store.AddToCrawl(goatscrape.LinkInfo{URL: "https://example.com/a"})
+
+	if got := store.Stats().QueueDepth; got != 0 {
+		t.Errorf("QueueDepth after re-adding an already-crawled link = %d, want 0", got)
+	}
+}
+
+func TestPersistentLinkStoreResumeAfterReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "links.db")
+
+	store, err := NewPersistentLinkStore(path)
+	if err != nil {
+		t.Fatalf("NewPersistentLinkStore() error = %v", err)
+	}
+
+	store.AddToCrawl(goatscrape.LinkInfo{URL: "https://example.com/queued"})
+	store.AddToCrawl(goatscrape.LinkInfo{URL: "https://example.com/done"})
+	store.MoveToCrawled("https://example.com/done")
+
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	reopened, err := NewPersistentLinkStore(path)
+	if err != nil {
+		t.Fatalf("NewPersistentLinkStore() (reopen) error = %v", err)
+	}
+	defer reopened.Close()
+
+	links := reopened.GetLinks(10)
+	if len(links) != 1 || links[0].URL != "https://example.com/queued" {
+		t.Errorf("GetLinks() after reopen = %v, want only the still-queued link", links)
+	}
+
+	// A link already marked crawled before the restart must not be
+	// re-queued if it's discovered again.
+	reopened.AddToCrawl(goatscrape.LinkInfo{URL: "https://example.com/done"})
+	if got := reopened.Stats().QueueDepth; got != 1 {
+		t.Errorf("QueueDepth after re-adding a crawled link post-resume = %d, want 1", got)
+	}
+}
+
+func TestPersistentLinkStoreMoveToCrawledRemovesFromQueue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "links.db")
+
+	store, err := NewPersistentLinkStore(path)
+	if err != nil {
+		t.Fatalf("NewPersistentLinkStore() error = %v", err)
+	}
+	defer store.Close()
+
+	store.AddToCrawl(goatscrape.LinkInfo{URL: "https://example.com/a"})
+	store.AddToCrawl(goatscrape.LinkInfo{URL: "https://example.com/b"})
+	store.MoveToCrawled("https://example.com/a")
+
+	links := store.GetLinks(10)
+	if len(links) != 1 || links[0].URL != "https://example.com/b" {
+		t.Errorf("GetLinks() after MoveToCrawled = %v, want only https://example.com/b", links)
+	}
+	if store.MoreToCrawl() != true {
+		t.Errorf("MoreToCrawl() = false, want true")
+	}
+}
@@ -0,0 +1,220 @@
+package plugins
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/stevie-holdway/goatscrape"
+)
+
+// WARCWriter wraps a goatscrape.RequestFunc so that every request/response
+// pair it makes is also archived to a WARC file (ISO 28500), gzip-per-record
+// so the output is replayable with tools like pywb or OpenWayback. Output
+// rotates to a new file once the current one reaches the maxSize given to
+// NewWARCWriter; a maxSize of zero or less disables rotation.
+type WARCWriter struct {
+	pathPrefix string
+	maxSize    int64
+
+	mu          sync.Mutex
+	file        *os.File
+	bytesInFile int64
+	fileIndex   int
+}
+
+// NewWARCWriter creates a WARCWriter that writes to pathPrefix, rotating
+// to pathPrefix.2, pathPrefix.3, ... once the current file reaches
+// maxSize bytes.
+func NewWARCWriter(pathPrefix string, maxSize int64) *WARCWriter {
+	return &WARCWriter{pathPrefix: pathPrefix, maxSize: maxSize}
+}
+
+// EnableWARC wraps a Spider's Fetch step so that every request it makes is
+// archived by a new WARCWriter writing to pathPrefix. It must be called
+// after Spider.Extender or Spider.Getter has been set: if Extender is set,
+// its Fetch method is wrapped; otherwise Getter is wrapped, the same as
+// before Extender existed.
+func EnableWARC(s *goatscrape.Spider, pathPrefix string, maxSize int64) (*WARCWriter, error) {
+	w := NewWARCWriter(pathPrefix, maxSize)
+
+	switch {
+	case s.Extender != nil:
+		s.Extender = &warcExtender{Extender: s.Extender, writer: w}
+	case s.Getter != nil:
+		s.Getter = w.Wrap(s.Getter)
+	default:
+		return nil, errors.New("spider must have an Extender or a Getter before WARC can be enabled")
+	}
+
+	return w, nil
+}
+
+// Wrap returns a RequestFunc that archives the pre-send request and
+// post-receive response made through next before returning the response
+// to the caller.
+func (w *WARCWriter) Wrap(next goatscrape.RequestFunc) goatscrape.RequestFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		reqBytes, _ := httputil.DumpRequest(req, true)
+		resp, err := next(req)
+		return w.archive(req.URL.String(), reqBytes, resp, err)
+	}
+}
+
+// archive writes reqBytes and a dump of resp to w as a WARC record pair if
+// the fetch succeeded (a failed fetch is not archived at all), then
+// returns resp and err unchanged. reqBytes must
+// be dumped by the caller before the fetch runs, since a request's Body is
+// consumed once it's sent.
+func (w *WARCWriter) archive(targetURI string, reqBytes []byte, resp *http.Response, err error) (*http.Response, error) {
+	if err != nil || resp == nil {
+		return resp, err
+	}
+
+	if respBytes, dumpErr := httputil.DumpResponse(resp, true); dumpErr == nil {
+		w.writeRecordPair(targetURI, reqBytes, respBytes)
+	}
+
+	return resp, err
+}
+
+// warcExtender wraps an existing goatscrape.Extender so every response
+// fetched through it is also archived by writer, leaving every other hook
+// to the wrapped Extender unchanged.
+type warcExtender struct {
+	goatscrape.Extender
+
+	writer *WARCWriter
+}
+
+func (e *warcExtender) Fetch(req *http.Request) (*http.Response, error) {
+	reqBytes, _ := httputil.DumpRequest(req, true)
+	resp, err := e.Extender.Fetch(req)
+	return e.writer.archive(req.URL.String(), reqBytes, resp, err)
+}
+
+// Close flushes and closes the current WARC file.
+func (w *WARCWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Close()
+}
+
+func (w *WARCWriter) writeRecordPair(targetURI string, reqBytes, respBytes []byte) {
+	// Archiving is best-effort: a failure to write a WARC record should
+	// never take down the crawl itself.
+	w.writeRecord("request", targetURI, "application/http;msgtype=request", reqBytes)
+	w.writeRecord("response", targetURI, "application/http;msgtype=response", respBytes)
+}
+
+func (w *WARCWriter) writeRecord(recordType, targetURI, contentType string, payload []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.ensureFileLocked(); err != nil {
+		return err
+	}
+
+	return w.writeRecordLocked(recordType, targetURI, contentType, payload)
+}
+
+// writeRecordLocked writes a single gzip-per-record WARC record to the
+// current file. w.mu must already be held and w.file must be open.
+func (w *WARCWriter) writeRecordLocked(recordType, targetURI, contentType string, payload []byte) error {
+	header := buildWarcHeader(recordType, targetURI, contentType, len(payload))
+
+	gz := gzip.NewWriter(w.file)
+	if _, err := gz.Write(header); err != nil {
+		gz.Close()
+		return err
+	}
+	if _, err := gz.Write(payload); err != nil {
+		gz.Close()
+		return err
+	}
+	if _, err := gz.Write([]byte("\r\n\r\n")); err != nil {
+		gz.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	if info, err := w.file.Stat(); err == nil {
+		w.bytesInFile = info.Size()
+	}
+
+	return nil
+}
+
+// ensureFileLocked opens the current output file, creating or rotating to
+// a new one (and writing its warcinfo record) as needed. w.mu must
+// already be held.
+func (w *WARCWriter) ensureFileLocked() error {
+	if w.file != nil && (w.maxSize <= 0 || w.bytesInFile < w.maxSize) {
+		return nil
+	}
+
+	if w.file != nil {
+		w.file.Close()
+	}
+
+	w.fileIndex++
+	path := w.pathPrefix
+	if w.fileIndex > 1 {
+		path = fmt.Sprintf("%s.%d", w.pathPrefix, w.fileIndex)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	w.file = f
+	w.bytesInFile = 0
+
+	return w.writeRecordLocked("warcinfo", "", "application/warc-fields",
+		[]byte("software: goatscrape\r\nformat: WARC File Format 1.0\r\n"))
+}
+
+// buildWarcHeader builds the WARC/1.0 record header block for a record of
+// length bytes. targetURI is omitted for records, such as warcinfo, that
+// don't describe a fetched URL.
+func buildWarcHeader(recordType, targetURI, contentType string, length int) []byte {
+	var b bytes.Buffer
+
+	b.WriteString("WARC/1.0\r\n")
+	b.WriteString("WARC-Type: " + recordType + "\r\n")
+	b.WriteString("WARC-Record-ID: " + newWarcRecordID() + "\r\n")
+	b.WriteString("WARC-Date: " + time.Now().UTC().Format("2006-01-02T15:04:05Z") + "\r\n")
+	if targetURI != "" {
+		b.WriteString("WARC-Target-URI: " + targetURI + "\r\n")
+	}
+	b.WriteString("Content-Type: " + contentType + "\r\n")
+	b.WriteString(fmt.Sprintf("Content-Length: %d\r\n", length))
+	b.WriteString("\r\n")
+
+	return b.Bytes()
+}
+
+// newWarcRecordID returns a freshly generated "<urn:uuid:...>" value, the
+// format WARC-Record-ID requires.
+func newWarcRecordID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("<urn:uuid:%x-%x-%x-%x-%x>", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,85 @@
+package plugins
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWARCWriterRecordFraming(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.warc.gz")
+	w := NewWARCWriter(path, 0)
+
+	payload := []byte("hello world")
+	if err := w.writeRecord("response", "https://example.com/x", "text/html", payload); err != nil {
+		t.Fatalf("writeRecord() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	all, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("reading gzip stream: %v", err)
+	}
+
+	// Each record is its own gzip member, so the decompressed stream is the
+	// warcinfo record followed by the response record we wrote, each
+	// starting with a "WARC/1.0" line.
+	records := strings.Split(string(all), "WARC/1.0\r\n")
+	if len(records) != 3 {
+		t.Fatalf("got %d records (split on WARC/1.0), want 2 (warcinfo + response): %q", len(records)-1, all)
+	}
+
+	record := records[2]
+	wantLines := []string{
+		"WARC-Type: response\r\n",
+		"WARC-Target-URI: https://example.com/x\r\n",
+		"Content-Type: text/html\r\n",
+		fmt.Sprintf("Content-Length: %d\r\n", len(payload)),
+	}
+	for _, want := range wantLines {
+		if !strings.Contains(record, want) {
+			t.Errorf("record missing %q, got %q", want, record)
+		}
+	}
+	if !strings.HasSuffix(record, string(payload)+"\r\n\r\n") {
+		t.Errorf("record does not end with payload + trailer, got %q", record)
+	}
+}
+
+func TestWARCWriterRotatesOnMaxSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.warc.gz")
+	w := NewWARCWriter(path, 1)
+
+	if err := w.writeRecord("response", "https://example.com/a", "text/html", []byte("a")); err != nil {
+		t.Fatalf("writeRecord() #1 error = %v", err)
+	}
+	if err := w.writeRecord("response", "https://example.com/b", "text/html", []byte("b")); err != nil {
+		t.Fatalf("writeRecord() #2 error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	rotatedPath := path + ".2"
+	for _, p := range []string{path, rotatedPath} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("expected output file %q to exist: %v", p, err)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package goatscrape
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// robotsPolicy holds the parsed rules from a single host's robots.txt
+// that apply to the spider's configured user agent token.
+type robotsPolicy struct {
+	disallow   []string
+	allow      []string
+	crawlDelay time.Duration
+}
+
+// allows reports whether path is permitted by the policy, using the usual
+// longest-matching-rule-wins robots.txt semantics.
+func (p *robotsPolicy) allows(path string) bool {
+	bestLen := -1
+	bestAllow := true
+
+	check := func(rules []string, allow bool) {
+		for _, r := range rules {
+			if r == "" {
+				continue
+			}
+			if strings.HasPrefix(path, r) && len(r) > bestLen {
+				bestLen = len(r)
+				bestAllow = allow
+			}
+		}
+	}
+
+	check(p.disallow, false)
+	check(p.allow, true)
+
+	return bestAllow
+}
+
+// robotsGroup is one "User-agent: ...\n(Disallow|Allow|Crawl-delay: ...)*"
+// block from a robots.txt file. A block can name more than one user agent
+// before its rules begin, in which case every name shares the same rules.
+type robotsGroup struct {
+	userAgents []string
+	disallow   []string
+	allow      []string
+	crawlDelay time.Duration
+}
+
+// parseRobotsTxt parses the body of a robots.txt file into groups and
+// returns only the rules from the single group that applies to userAgent,
+// falling back to the "*" group when no group names userAgent
+// specifically. Per the robots.txt spec, groups are not merged: only the
+// best-matching group's rules apply, never an accumulation across groups.
+func parseRobotsTxt(body []byte, userAgent string) *robotsPolicy {
+	var groups []*robotsGroup
+	var current *robotsGroup
+	inUserAgentLines := false
+
+	scanner := bufio.NewScanner(strings.NewReader(string(body)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		field := strings.ToLower(strings.TrimSpace(parts[0]))
+		value := strings.TrimSpace(parts[1])
+
+		if field == "user-agent" {
+			// Consecutive User-agent lines belong to the same group; a
+			// User-agent line following a rule line starts a new one.
+			if current == nil || !inUserAgentLines {
+				current = &robotsGroup{}
+				groups = append(groups, current)
+			}
+			current.userAgents = append(current.userAgents, value)
+			inUserAgentLines = true
+			continue
+		}
+
+		inUserAgentLines = false
+		if current == nil {
+			continue
+		}
+
+		switch field {
+		case "disallow":
+			current.disallow = append(current.disallow, value)
+		case "allow":
+			current.allow = append(current.allow, value)
+		case "crawl-delay":
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+				current.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+
+	group := bestMatchingGroup(groups, userAgent)
+	if group == nil {
+		return &robotsPolicy{}
+	}
+	return &robotsPolicy{disallow: group.disallow, allow: group.allow, crawlDelay: group.crawlDelay}
+}
+
+// bestMatchingGroup returns the group that names userAgent specifically, or
+// else the first group that names "*", or nil if neither exists.
+func bestMatchingGroup(groups []*robotsGroup, userAgent string) *robotsGroup {
+	var wildcard *robotsGroup
+	for _, g := range groups {
+		for _, ua := range g.userAgents {
+			if strings.EqualFold(ua, userAgent) {
+				return g
+			}
+			if ua == "*" && wildcard == nil {
+				wildcard = g
+			}
+		}
+	}
+	return wildcard
+}
+
+// hostState is the per-host bookkeeping a HostThrottler keeps: the cached
+// robots.txt policy (cached once its first fetch completes; lookups that
+// race that first fetch each fetch it too) and the next time a request to
+// the host is allowed to fire.
+type hostState struct {
+	fetchedRobots bool
+	policy        *robotsPolicy
+	nextAvailable time.Time
+	lastFetch     time.Time
+}
+
+// HostThrottler queues requests per host so that a Spider's
+// MaxConcurrentRequests fan-out never issues two requests to the same
+// host closer together than its crawl-delay, whether that delay comes
+// from robots.txt or Spider.DefaultCrawlDelay.
+type HostThrottler struct {
+	mu    sync.Mutex
+	hosts map[string]*hostState
+}
+
+// NewHostThrottler returns an empty HostThrottler ready for use.
+func NewHostThrottler() *HostThrottler {
+	return &HostThrottler{hosts: make(map[string]*hostState)}
+}
+
+func (h *HostThrottler) stateFor(host string) *hostState {
+	st, ok := h.hosts[host]
+	if !ok {
+		st = &hostState{}
+		h.hosts[host] = st
+	}
+	return st
+}
+
+// PolicyFor returns the cached robots.txt policy for host, calling fetch
+// to populate the cache the first time the host is seen.
+func (h *HostThrottler) PolicyFor(host string, fetch func() *robotsPolicy) *robotsPolicy {
+	h.mu.Lock()
+	st := h.stateFor(host)
+	if st.fetchedRobots {
+		policy := st.policy
+		h.mu.Unlock()
+		return policy
+	}
+	h.mu.Unlock()
+
+	policy := fetch()
+
+	h.mu.Lock()
+	st.policy = policy
+	st.fetchedRobots = true
+	h.mu.Unlock()
+
+	return policy
+}
+
+// Reserve records that a request to host is about to be made and returns
+// how long the caller should wait beforehand so that it lands no sooner
+// than delay after the previous request to that host.
+func (h *HostThrottler) Reserve(host string, delay time.Duration) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	st := h.stateFor(host)
+
+	now := time.Now()
+	wait := time.Duration(0)
+	if st.nextAvailable.After(now) {
+		wait = st.nextAvailable.Sub(now)
+	}
+
+	st.lastFetch = now.Add(wait)
+	st.nextAvailable = st.lastFetch.Add(delay)
+
+	return wait
+}
+
+// LastFetch returns the time the most recent request to host was made (or
+// scheduled to be made), or the zero time if host has not been seen yet.
+func (h *HostThrottler) LastFetch(host string) time.Time {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.stateFor(host).lastFetch
+}
+
+// robotsClient is used to fetch robots.txt independently of the spider's
+// configured Getter, since a content-filtering Getter - one built by
+// plugins.ContentAwareGetter without "text/plain" among its types, say -
+// may reject the plain-text response before it gets this far.
+var robotsClient http.Client
+
+func (s *Spider) fetchRobotsPolicy(scheme, host string) *robotsPolicy {
+	if body := s.Extender.RequestRobots(host); body != nil {
+		return parseRobotsTxt(body, s.UserAgentToken)
+	}
+
+	resp, err := robotsClient.Get(scheme + "://" + host + "/robots.txt")
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil
+	}
+
+	return parseRobotsTxt(body, s.UserAgentToken)
+}
+
+func (s *Spider) policyFor(u *url.URL) *robotsPolicy {
+	return s.throttler.PolicyFor(u.Host, func() *robotsPolicy {
+		return s.fetchRobotsPolicy(u.Scheme, u.Host)
+	})
+}
+
+// robotsAllow reports whether u may be fetched under the host's
+// robots.txt policy for the spider's UserAgentToken.
+func (s *Spider) robotsAllow(u *url.URL) bool {
+	policy := s.policyFor(u)
+	if policy == nil {
+		return true
+	}
+	return policy.allows(u.Path)
+}
+
+// crawlDelayFor returns the crawl-delay that should be honoured before the
+// next request to u's host: Extender.ComputeDelay's result if positive,
+// else the host's robots.txt Crawl-delay if RespectRobots is set and one
+// was given, otherwise Spider.DefaultCrawlDelay.
+func (s *Spider) crawlDelayFor(u *url.URL) time.Duration {
+	if d := s.Extender.ComputeDelay(u.Host, s.throttler.LastFetch(u.Host)); d > 0 {
+		return d
+	}
+
+	if !s.RespectRobots {
+		return s.DefaultCrawlDelay
+	}
+
+	policy := s.policyFor(u)
+	if policy != nil && policy.crawlDelay > 0 {
+		return policy.crawlDelay
+	}
+
+	return s.DefaultCrawlDelay
+}
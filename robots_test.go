@@ -0,0 +1,66 @@
+package goatscrape
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRobotsTxt(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		userAgent  string
+		disallow   []string
+		allow      []string
+		crawlDelay time.Duration
+	}{
+		{
+			name:      "specific group replaces earlier wildcard group, not merges with it",
+			body:      "User-agent: *\nDisallow: /a\nUser-agent: MyBot\nDisallow: /b",
+			userAgent: "MyBot",
+			disallow:  []string{"/b"},
+		},
+		{
+			name:      "consecutive user-agent lines share the rules that follow",
+			body:      "User-agent: MyBot\nUser-agent: Googlebot\nDisallow: /private",
+			userAgent: "MyBot",
+			disallow:  []string{"/private"},
+		},
+		{
+			name:      "falls back to the wildcard group when no specific group matches",
+			body:      "User-agent: Googlebot\nDisallow: /a\nUser-agent: *\nDisallow: /b",
+			userAgent: "MyBot",
+			disallow:  []string{"/b"},
+		},
+		{
+			name:      "unrelated group's rules are ignored entirely",
+			body:      "User-agent: MyBot\nDisallow: /private\nUser-agent: Googlebot\nDisallow: /other",
+			userAgent: "MyBot",
+			disallow:  []string{"/private"},
+		},
+		{
+			name:       "crawl-delay is scoped to its own group",
+			body:       "User-agent: *\nCrawl-delay: 1\nUser-agent: MyBot\nCrawl-delay: 5\nDisallow: /b",
+			userAgent:  "MyBot",
+			disallow:   []string{"/b"},
+			crawlDelay: 5 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := parseRobotsTxt([]byte(tt.body), tt.userAgent)
+
+			if !reflect.DeepEqual(policy.disallow, tt.disallow) {
+				t.Errorf("disallow = %v, want %v", policy.disallow, tt.disallow)
+			}
+			if !reflect.DeepEqual(policy.allow, tt.allow) {
+				t.Errorf("allow = %v, want %v", policy.allow, tt.allow)
+			}
+			if policy.crawlDelay != tt.crawlDelay {
+				t.Errorf("crawlDelay = %v, want %v", policy.crawlDelay, tt.crawlDelay)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package goatscrape
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Scope defines a pluggable filter that decides whether a URL discovered
+// during a crawl is in scope and should be added to the crawl. Spider.Scopes
+// holds an ordered set of Scopes; a URL is only crawled if every Scope
+// agrees, so replaces the old Spider.AllowedDomains and
+// Spider.DisallowedPages fields with composable building blocks.
+type Scope interface {
+	// Check returns true if uri, discovered at depth from sourceURL, is
+	// within scope and should be crawled.
+	Check(uri string, depth int, sourceURL string) bool
+}
+
+// SchemeScope restricts the crawl to a fixed set of URL schemes, such as
+// "http" and "https".
+type SchemeScope struct {
+	Schemes []string
+}
+
+func (s *SchemeScope) Check(uri string, depth int, sourceURL string) bool {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return false
+	}
+	for _, scheme := range s.Schemes {
+		if u.Scheme == scheme {
+			return true
+		}
+	}
+	return false
+}
+
+// HostScope restricts the crawl to an exact set of hostnames.
+type HostScope struct {
+	Hosts []string
+}
+
+func (s *HostScope) Check(uri string, depth int, sourceURL string) bool {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return false
+	}
+	for _, host := range s.Hosts {
+		if u.Host == host {
+			return true
+		}
+	}
+	return false
+}
+
+// registeredDomain returns host's eTLD+1 - its registered domain - using
+// the public suffix list via golang.org/x/net/publicsuffix, so multi-label
+// public suffixes (e.g. "example.co.uk", "foo.github.io") resolve to the
+// right registered domain rather than being split naively on the last two
+// labels. If host isn't eligible for an eTLD+1 (it's a bare public suffix,
+// or itself not a valid domain), host is returned unchanged.
+func registeredDomain(host string) string {
+	host = strings.TrimSuffix(host, ".")
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return domain
+}
+
+// DomainScope restricts the crawl to a set of registered domains, so
+// "www.example.com" and "shop.example.com" both match a configured
+// "example.com".
+type DomainScope struct {
+	Domains []string
+}
+
+func (s *DomainScope) Check(uri string, depth int, sourceURL string) bool {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return false
+	}
+	domain := registeredDomain(u.Hostname())
+	for _, d := range s.Domains {
+		if domain == d {
+			return true
+		}
+	}
+	return false
+}
+
+// DepthScope rejects any URL discovered more than MaxDepth hops from a
+// starting URL.
+type DepthScope struct {
+	MaxDepth int
+}
+
+func (s *DepthScope) Check(uri string, depth int, sourceURL string) bool {
+	return depth <= s.MaxDepth
+}
+
+// RegexScope matches a URL against Pattern. Allow controls whether a match
+// permits the URL (true) or rejects it (false, a drop-in replacement for
+// the old Spider.DisallowedPages).
+type RegexScope struct {
+	Pattern *regexp.Regexp
+	Allow   bool
+}
+
+func (s *RegexScope) Check(uri string, depth int, sourceURL string) bool {
+	matched := s.Pattern.MatchString(uri)
+	if s.Allow {
+		return matched
+	}
+	return !matched
+}
+
+// SeedScope only allows URLs that are "under" one of the given seed URLs:
+// same scheme and host, with the URL's path equal to or below the seed's
+// path, compared segment by segment (see pathUnder).
+type SeedScope struct {
+	Seeds []string
+}
+
+func (s *SeedScope) Check(uri string, depth int, sourceURL string) bool {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return false
+	}
+	for _, seed := range s.Seeds {
+		su, err := url.Parse(seed)
+		if err != nil {
+			continue
+		}
+		if u.Scheme == su.Scheme && u.Host == su.Host && pathUnder(u.Path, su.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathUnder reports whether path is seedPath itself or a descendant of it,
+// comparing whole path segments so that a seed of "/blog" admits "/blog/foo"
+// but not the unrelated "/blogpost-unrelated".
+func pathUnder(path, seedPath string) bool {
+	seedPath = strings.TrimSuffix(seedPath, "/")
+	if path == seedPath {
+		return true
+	}
+	return strings.HasPrefix(path, seedPath+"/")
+}
+
+// MaxHostsScope caps the number of unique hostnames a crawl will visit,
+// guarding against subdomain-crawling traps where every page lives on its
+// own host under the same registered domain.
+type MaxHostsScope struct {
+	Max int
+
+	mu    sync.Mutex
+	hosts map[string]bool
+}
+
+func (s *MaxHostsScope) Check(uri string, depth int, sourceURL string) bool {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.hosts == nil {
+		s.hosts = make(map[string]bool)
+	}
+	if s.hosts[u.Host] {
+		return true
+	}
+	if len(s.hosts) >= s.Max {
+		return false
+	}
+	s.hosts[u.Host] = true
+	return true
+}
+
+// MaxSubdomainsScope caps the number of unique subdomains seen per
+// registered domain, for the same reason as MaxHostsScope but scoped per
+// domain so the limit applies independently to each site the crawl visits.
+type MaxSubdomainsScope struct {
+	Max int
+
+	mu      sync.Mutex
+	domains map[string]map[string]bool
+}
+
+func (s *MaxSubdomainsScope) Check(uri string, depth int, sourceURL string) bool {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return false
+	}
+	domain := registeredDomain(u.Hostname())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.domains == nil {
+		s.domains = make(map[string]map[string]bool)
+	}
+	hosts, ok := s.domains[domain]
+	if !ok {
+		hosts = make(map[string]bool)
+		s.domains[domain] = hosts
+	}
+	if hosts[u.Host] {
+		return true
+	}
+	if len(hosts) >= s.Max {
+		return false
+	}
+	hosts[u.Host] = true
+	return true
+}
@@ -0,0 +1,117 @@
+package goatscrape
+
+import "testing"
+
+func TestSeedScopeCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		seed string
+		uri  string
+		want bool
+	}{
+		{
+			name: "exact seed matches",
+			seed: "https://example.com/blog",
+			uri:  "https://example.com/blog",
+			want: true,
+		},
+		{
+			name: "child of seed matches",
+			seed: "https://example.com/blog",
+			uri:  "https://example.com/blog/post-1",
+			want: true,
+		},
+		{
+			name: "sibling path that merely shares a prefix does not match",
+			seed: "https://example.com/blog",
+			uri:  "https://example.com/blogpost-unrelated",
+			want: false,
+		},
+		{
+			name: "different host does not match",
+			seed: "https://example.com/blog",
+			uri:  "https://other.com/blog/post-1",
+			want: false,
+		},
+		{
+			name: "seed with trailing slash still matches children",
+			seed: "https://example.com/blog/",
+			uri:  "https://example.com/blog/post-1",
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &SeedScope{Seeds: []string{tt.seed}}
+			if got := s.Check(tt.uri, 0, tt.seed); got != tt.want {
+				t.Errorf("Check(%q) = %v, want %v", tt.uri, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisteredDomain(t *testing.T) {
+	tests := []struct {
+		name string
+		host string
+		want string
+	}{
+		{name: "simple two-label domain", host: "www.example.com", want: "example.com"},
+		{
+			name: "multi-label public suffix resolves to the right registered domain",
+			host: "www.example.co.uk",
+			want: "example.co.uk",
+		},
+		{
+			name: "github.io subdomain resolves per-user, not to github.io itself",
+			host: "foo.github.io",
+			want: "foo.github.io",
+		},
+		{
+			name: "deeper subdomain under a multi-label suffix still resolves correctly",
+			host: "shop.staging.example.co.uk",
+			want: "example.co.uk",
+		},
+		{name: "trailing dot is ignored", host: "www.example.com.", want: "example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := registeredDomain(tt.host); got != tt.want {
+				t.Errorf("registeredDomain(%q) = %q, want %q", tt.host, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDomainScopeCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		domains []string
+		uri     string
+		want    bool
+	}{
+		{
+			name:    "subdomain under a configured multi-label registered domain matches",
+			domains: []string{"example.co.uk"},
+			uri:     "https://shop.example.co.uk/items",
+			want:    true,
+		},
+		{
+			name:    "a different registered domain under the same public suffix does not match",
+			domains: []string{"example.co.uk"},
+			uri:     "https://other.co.uk/items",
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &DomainScope{Domains: tt.domains}
+			if got := s.Check(tt.uri, 0, ""); got != tt.want {
+				t.Errorf("Check(%q) = %v, want %v", tt.uri, got, tt.want)
+			}
+		})
+	}
+}